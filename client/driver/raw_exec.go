@@ -0,0 +1,235 @@
+package driver
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// rawExecConfigOption is the client config option that must be set to
+// "true" before the raw_exec driver will fingerprint itself as usable. It
+// defaults to disabled since raw_exec gives tasks no isolation from the
+// host.
+const rawExecConfigOption = "driver.raw_exec.enable"
+
+func init() {
+	RegisterConfigValidator("raw_exec", validateRawExecConfig)
+}
+
+// validateRawExecConfig is raw_exec's ConfigValidatorFunc.
+func validateRawExecConfig(config map[string]string) error {
+	if config["command"] == "" {
+		return fmt.Errorf("missing 'command' for raw_exec driver")
+	}
+	return nil
+}
+
+// RawExecDriver fork/execs tasks directly on the host with no cgroup or
+// chroot isolation.
+type RawExecDriver struct {
+	DriverContext
+}
+
+// NewRawExecDriver returns a new raw_exec driver.
+func NewRawExecDriver(ctx *DriverContext) *RawExecDriver {
+	return &RawExecDriver{DriverContext: *ctx}
+}
+
+// Fingerprint implements the Driver interface.
+func (d *RawExecDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool, error) {
+	if cfg.Options[rawExecConfigOption] != "true" {
+		return false, nil
+	}
+	node.Attributes["driver.raw_exec"] = "1"
+	return true, nil
+}
+
+// Start implements the Driver interface.
+func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle, error) {
+	command := task.Config["command"]
+	if command == "" {
+		return nil, fmt.Errorf("missing 'command' for raw_exec driver")
+	}
+
+	var args []string
+	if raw := task.Config["args"]; raw != "" {
+		args = strings.Fields(raw)
+	}
+
+	cmd := exec.Command(command, args...)
+	cmd.Dir = ctx.AllocDir.SharedDir
+	cmd.Env = ctx.TaskEnv.EnvList()
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %v", err)
+	}
+
+	h := &rawExecHandle{
+		proc:     cmd.Process,
+		userPid:  cmd.Process.Pid,
+		allocDir: ctx.AllocDir,
+		logger:   d.logger,
+		doneCh:   make(chan struct{}),
+		waitCh:   make(chan error, 1),
+	}
+	go h.run(cmd)
+	return h, nil
+}
+
+// Open implements the Driver interface, reattaching to a task that was
+// started by a previous instance of the client.
+func (d *RawExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	pid, err := strconv.Atoi(handleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse handle '%s': %v", handleID, err)
+	}
+
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find process %d: %v", pid, err)
+	}
+
+	h := &rawExecHandle{
+		proc:     proc,
+		userPid:  pid,
+		allocDir: ctx.AllocDir,
+		logger:   d.logger,
+		doneCh:   make(chan struct{}),
+		waitCh:   make(chan error, 1),
+	}
+	go h.reattach()
+	return h, nil
+}
+
+// rawExecHandle is returned from Start/Open and tracks the state of a
+// running raw_exec task.
+type rawExecHandle struct {
+	proc     *os.Process
+	userPid  int
+	allocDir *allocdir.AllocDir
+	logger   *log.Logger
+	doneCh   chan struct{}
+	waitCh   chan error
+
+	// statsLock guards lastTotalTicks/lastSampledAt, which Stats uses to
+	// turn two /proc samples into a percent-CPU figure.
+	statsLock      sync.Mutex
+	lastTotalTicks uint64
+	lastSampledAt  time.Time
+}
+
+func (h *rawExecHandle) run(cmd *exec.Cmd) {
+	err := cmd.Wait()
+	close(h.doneCh)
+	h.waitCh <- err
+	close(h.waitCh)
+}
+
+func (h *rawExecHandle) reattach() {
+	state, err := h.proc.Wait()
+	if err == nil && !state.Success() {
+		err = fmt.Errorf("task exited with non-zero status")
+	}
+	close(h.doneCh)
+	h.waitCh <- err
+	close(h.waitCh)
+}
+
+func (h *rawExecHandle) ID() string {
+	return strconv.Itoa(h.userPid)
+}
+
+func (h *rawExecHandle) WaitCh() chan error {
+	return h.waitCh
+}
+
+// Update is a no-op for raw_exec; there's nothing about an already-running
+// process that can be changed in place.
+func (h *rawExecHandle) Update(task *structs.Task) error {
+	return nil
+}
+
+func (h *rawExecHandle) Kill() error {
+	if err := h.proc.Kill(); err != nil {
+		return err
+	}
+	<-h.doneCh
+	return nil
+}
+
+// Stats implements the AllocStatsReporter interface, reporting CPU and
+// memory usage for the task's process tree by reading /proc directly.
+// raw_exec tasks aren't placed in a cgroup, so there's no accounting
+// subsystem to read from as the other drivers do.
+func (h *rawExecHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	pids, err := pidTree(h.userPid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine process tree for pid %d: %v", h.userPid, err)
+	}
+
+	var rssBytes uint64
+	var totalTicks uint64
+	var readBytes, writeBytes uint64
+	pidStats := make(map[string]*cstructs.ResourceUsage, len(pids))
+	for _, pid := range pids {
+		rss, ticks, err := readProcStat(pid)
+		if err != nil {
+			// The process may have exited between listing the tree and
+			// reading its stats; drop it from this sample rather than
+			// failing the whole call.
+			continue
+		}
+		rssBytes += rss
+		totalTicks += ticks
+
+		pidRead, pidWrite, err := readProcIO(pid)
+		if err != nil {
+			// /proc/<pid>/io requires CAP_SYS_PTRACE (or matching uid) on
+			// some systems; degrade to zero IO for this pid rather than
+			// dropping its CPU/memory numbers too.
+			pidRead, pidWrite = 0, 0
+		}
+		readBytes += pidRead
+		writeBytes += pidWrite
+
+		pidStats[strconv.Itoa(pid)] = &cstructs.ResourceUsage{
+			MemoryStats: &cstructs.MemoryStats{RSS: rss},
+			IoStats:     &cstructs.IoStats{ReadBytes: pidRead, WriteBytes: pidWrite},
+		}
+	}
+
+	now := time.Now()
+	h.statsLock.Lock()
+	var percent float64
+	if !h.lastSampledAt.IsZero() && totalTicks >= h.lastTotalTicks {
+		if secs := now.Sub(h.lastSampledAt).Seconds(); secs > 0 {
+			percent = (float64(totalTicks-h.lastTotalTicks) / clockTicksPerSec / secs) * 100
+		}
+	}
+	h.lastTotalTicks = totalTicks
+	h.lastSampledAt = now
+	h.statsLock.Unlock()
+
+	return &cstructs.TaskResourceUsage{
+		ResourceUsage: &cstructs.ResourceUsage{
+			MemoryStats: &cstructs.MemoryStats{RSS: rssBytes},
+			CpuStats: &cstructs.CpuStats{
+				Percent:    percent,
+				TotalTicks: float64(totalTicks),
+			},
+			IoStats: &cstructs.IoStats{ReadBytes: readBytes, WriteBytes: writeBytes},
+		},
+		Timestamp: now.UTC().UnixNano(),
+		Pids:      pidStats,
+	}, nil
+}
@@ -0,0 +1,117 @@
+// +build linux
+
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// clockTicksPerSec is the kernel's USER_HZ value used to convert the
+// jiffie counts in /proc/<pid>/stat into seconds. It's 100 on every Linux
+// platform Nomad supports, so it's hardcoded rather than shelled out to
+// getconf.
+const clockTicksPerSec = 100.0
+
+// pidTree returns pid and all of its descendants, discovered by walking
+// /proc/<pid>/task/*/children, which the kernel maintains for every
+// process.
+func pidTree(pid int) ([]int, error) {
+	pids := []int{pid}
+	queue := []int{pid}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		taskDir := fmt.Sprintf("/proc/%d/task", cur)
+		tasks, err := ioutil.ReadDir(taskDir)
+		if err != nil {
+			// The process may have already exited; skip it and keep
+			// walking the rest of the tree rather than failing the walk.
+			continue
+		}
+
+		for _, task := range tasks {
+			raw, err := ioutil.ReadFile(filepath.Join(taskDir, task.Name(), "children"))
+			if err != nil {
+				continue
+			}
+			for _, field := range strings.Fields(string(raw)) {
+				child, err := strconv.Atoi(field)
+				if err != nil {
+					continue
+				}
+				pids = append(pids, child)
+				queue = append(queue, child)
+			}
+		}
+	}
+	return pids, nil
+}
+
+// readProcStat returns the resident set size in bytes and the total CPU
+// time in clock ticks (utime+stime) for pid, read from /proc/<pid>/statm
+// and /proc/<pid>/stat.
+func readProcStat(pid int) (rssBytes uint64, ticks uint64, err error) {
+	statm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	statmFields := strings.Fields(string(statm))
+	if len(statmFields) < 2 {
+		return 0, 0, fmt.Errorf("unexpected statm format for pid %d", pid)
+	}
+	pages, err := strconv.ParseUint(statmFields[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	rssBytes = pages * uint64(os.Getpagesize())
+
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The comm field can itself contain spaces/parens, so split on the
+	// last ')' rather than just fields[1].
+	end := strings.LastIndex(string(stat), ")")
+	if end == -1 {
+		return 0, 0, fmt.Errorf("unexpected stat format for pid %d", pid)
+	}
+	rest := strings.Fields(string(stat)[end+1:])
+	if len(rest) < 13 {
+		return 0, 0, fmt.Errorf("unexpected stat format for pid %d", pid)
+	}
+	// utime and stime are fields 14 and 15 overall; rest[0] is field 3.
+	utime, _ := strconv.ParseUint(rest[11], 10, 64)
+	stime, _ := strconv.ParseUint(rest[12], 10, 64)
+	return rssBytes, utime + stime, nil
+}
+
+// readProcIO returns the cumulative bytes read from and written to storage
+// for pid, read from the read_bytes/write_bytes lines of /proc/<pid>/io.
+// Those lines count actual block I/O rather than buffered rchar/wchar, so
+// they're a closer match for the IO a cgroup-backed driver would report.
+func readProcIO(pid int) (readBytes uint64, writeBytes uint64, err error) {
+	raw, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/io", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "read_bytes:":
+			readBytes, _ = strconv.ParseUint(fields[1], 10, 64)
+		case "write_bytes:":
+			writeBytes, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	return readBytes, writeBytes, nil
+}
@@ -0,0 +1,26 @@
+// +build !linux
+
+package driver
+
+import "fmt"
+
+// clockTicksPerSec is referenced by Stats on every platform, but outside of
+// Linux readProcStat always fails, so no ticks are sampled and the reported
+// percent-CPU is always 0.
+const clockTicksPerSec = 100.0
+
+// pidTree returns just pid itself; walking a process tree without
+// /proc is not yet implemented on this platform.
+func pidTree(pid int) ([]int, error) {
+	return []int{pid}, nil
+}
+
+// readProcStat is not implemented on this platform.
+func readProcStat(pid int) (rssBytes uint64, ticks uint64, err error) {
+	return 0, 0, fmt.Errorf("raw_exec resource usage reporting is not implemented on this platform")
+}
+
+// readProcIO is not implemented on this platform.
+func readProcIO(pid int) (readBytes uint64, writeBytes uint64, err error) {
+	return 0, 0, fmt.Errorf("raw_exec resource usage reporting is not implemented on this platform")
+}
@@ -13,6 +13,15 @@ import (
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+func TestRawExecDriver_ConfigValidatorRegistered(t *testing.T) {
+	if err := BuiltinConfigValidators.Validate("raw_exec", map[string]string{"command": "/bin/sleep"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := BuiltinConfigValidators.Validate("raw_exec", map[string]string{}); err == nil {
+		t.Fatalf("expected validation error for missing command")
+	}
+}
+
 func TestRawExecDriver_Fingerprint(t *testing.T) {
 	d := NewRawExecDriver(testDriverContext(""))
 	node := &structs.Node{
@@ -177,6 +186,63 @@ func TestRawExecDriver_Start_Wait_AllocDir(t *testing.T) {
 	}
 }
 
+func TestRawExecDriver_Stats(t *testing.T) {
+	task := &structs.Task{
+		Name: "sleep",
+		Config: map[string]string{
+			"command": "/bin/sleep",
+			"args":    "1",
+		},
+	}
+
+	driverCtx := testDriverContext(task.Name)
+	ctx := testDriverExecContext(task, driverCtx)
+	defer ctx.AllocDir.Destroy()
+
+	d := NewRawExecDriver(driverCtx)
+	handle, err := d.Start(ctx, task)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Give the child a moment to actually be running before sampling.
+	time.Sleep(100 * time.Millisecond)
+
+	reporter, ok := handle.(AllocStatsReporter)
+	if !ok {
+		t.Fatalf("rawExecHandle does not implement AllocStatsReporter")
+	}
+	usage, err := reporter.Stats()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if usage.ResourceUsage.MemoryStats.RSS == 0 {
+		t.Fatalf("expected non-zero RSS")
+	}
+	if usage.ResourceUsage.CpuStats.Percent < 0 || usage.ResourceUsage.CpuStats.Percent > 100 {
+		t.Fatalf("unexpected CPU percent: %v", usage.ResourceUsage.CpuStats.Percent)
+	}
+	if usage.ResourceUsage.IoStats == nil {
+		t.Fatalf("expected IO stats to be populated")
+	}
+
+	select {
+	case <-handle.WaitCh():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	// Stats on an exited pid should degrade gracefully rather than
+	// erroring or panicking.
+	usage, err = reporter.Stats()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if usage.ResourceUsage.MemoryStats.RSS != 0 {
+		t.Fatalf("expected zero RSS for an exited pid, got %v", usage.ResourceUsage.MemoryStats.RSS)
+	}
+}
+
 func TestRawExecDriver_Start_Kill_Wait(t *testing.T) {
 	task := &structs.Task{
 		Name: "sleep",
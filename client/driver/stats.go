@@ -0,0 +1,14 @@
+package driver
+
+import cstructs "github.com/hashicorp/nomad/client/driver/structs"
+
+// AllocStatsReporter is implemented by driver handles that can report
+// runtime resource usage for the task they're tracking. The client
+// type-asserts a DriverHandle against this interface to decide whether to
+// poll it for stats, so any driver opts in just by implementing Stats -
+// no change to the DriverHandle interface itself is needed.
+type AllocStatsReporter interface {
+	Stats() (*cstructs.TaskResourceUsage, error)
+}
+
+var _ AllocStatsReporter = (*rawExecHandle)(nil)
@@ -0,0 +1,52 @@
+package driver
+
+import "sync"
+
+// ConfigValidatorFunc validates a single task's driver-specific config map.
+type ConfigValidatorFunc func(config map[string]string) error
+
+// ConfigValidatorRegistry maps driver names to their config validator, so
+// jobspec.ParseWithOptions can validate task configs by name without
+// instantiating a driver. jobspec imports this package to use it.
+type ConfigValidatorRegistry struct {
+	lock       sync.RWMutex
+	validators map[string]ConfigValidatorFunc
+}
+
+// NewConfigValidatorRegistry returns an empty registry.
+func NewConfigValidatorRegistry() *ConfigValidatorRegistry {
+	return &ConfigValidatorRegistry{
+		validators: make(map[string]ConfigValidatorFunc),
+	}
+}
+
+// Register associates fn with the named driver, replacing any validator
+// previously registered under that name.
+func (r *ConfigValidatorRegistry) Register(name string, fn ConfigValidatorFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.validators[name] = fn
+}
+
+// Validate runs the validator registered for name against config. If no
+// validator is registered for name, Validate returns nil so a caller that
+// only registered a subset of drivers doesn't fail closed on the rest.
+func (r *ConfigValidatorRegistry) Validate(name string, config map[string]string) error {
+	r.lock.RLock()
+	fn, ok := r.validators[name]
+	r.lock.RUnlock()
+	if !ok {
+		return nil
+	}
+	return fn(config)
+}
+
+// BuiltinConfigValidators is the registry jobspec.DefaultParseOptions uses,
+// populated by each driver's init().
+var BuiltinConfigValidators = NewConfigValidatorRegistry()
+
+// RegisterConfigValidator registers fn as the config validator for name in
+// BuiltinConfigValidators.
+func RegisterConfigValidator(name string, fn ConfigValidatorFunc) {
+	BuiltinConfigValidators.Register(name, fn)
+}
@@ -0,0 +1,179 @@
+package jobspec
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ParseOptions controls how Parse validates driver-specific task configs.
+type ParseOptions struct {
+	// Validators supplies the per-driver config validation consulted by
+	// parseTasks. A nil registry, or a registry with no entry for a given
+	// driver, skips validation for that driver rather than erroring, so a
+	// caller that only registers a handful of drivers doesn't fail closed
+	// on the rest.
+	Validators *driver.ConfigValidatorRegistry
+}
+
+// DefaultParseOptions validates against driver.BuiltinConfigValidators, the
+// registry the client process populates from every driver it links.
+func DefaultParseOptions() ParseOptions {
+	return ParseOptions{Validators: driver.BuiltinConfigValidators}
+}
+
+// Parse parses a jobspec from r using DefaultParseOptions.
+func Parse(r io.Reader) (*structs.Job, error) {
+	return ParseWithOptions(r, DefaultParseOptions())
+}
+
+// ParseWithOptions parses a jobspec from r the same way Parse does, but
+// lets the caller supply its own validator registry (or none at all), so a
+// caller such as Terraform's nomad provider, which only needs the parsed
+// structs.Job, can skip driver config validation. This package still
+// imports client/driver for the registry type, so linking it still links
+// that package.
+func ParseWithOptions(r io.Reader, opts ParseOptions) (*structs.Job, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jobspec: %v", err)
+	}
+
+	root, err := hcl.Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing jobspec: %v", err)
+	}
+
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("jobspec: root should be an object")
+	}
+
+	jobItems := list.Filter("job")
+	if len(jobItems.Items) != 1 {
+		return nil, fmt.Errorf("jobspec: exactly one 'job' stanza is required")
+	}
+
+	jobObj, ok := jobItems.Items[0].Val.(*ast.ObjectType)
+	if !ok {
+		return nil, fmt.Errorf("jobspec: 'job' should be an object")
+	}
+
+	var job structs.Job
+	var jobMap map[string]interface{}
+	if err := hcl.DecodeObject(&jobMap, jobObj); err != nil {
+		return nil, fmt.Errorf("jobspec: %v", err)
+	}
+	delete(jobMap, "task")
+	if err := mapstructure.WeakDecode(jobMap, &job); err != nil {
+		return nil, fmt.Errorf("jobspec: %v", err)
+	}
+	if len(jobItems.Items[0].Keys) > 0 {
+		job.ID = jobItems.Items[0].Keys[0].Token.Value().(string)
+		job.Name = job.ID
+	}
+
+	if taskItems := jobObj.List.Filter("task"); len(taskItems.Items) > 0 {
+		tasks, err := parseTasks(taskItems, opts)
+		if err != nil {
+			return nil, err
+		}
+		job.TaskGroups = []*structs.TaskGroup{{Tasks: tasks}}
+	}
+
+	return &job, nil
+}
+
+// parseTasks decodes each "task" stanza into a structs.Task and validates
+// its driver config through opts.Validators.
+//
+// This used to instantiate the named driver directly - via
+// client/driver.NewDriver - just to call Validate on it. Looking the
+// validator up in a registry supplied through ParseOptions means a consumer
+// can disable driver validation entirely, or supply a registry populated
+// with only the drivers it cares about. The client/driver import remains,
+// so embedding this package still links the driver package.
+func parseTasks(list *ast.ObjectList, opts ParseOptions) ([]*structs.Task, error) {
+	tasks := make([]*structs.Task, 0, len(list.Items))
+	for _, item := range list.Items {
+		taskObj, ok := item.Val.(*ast.ObjectType)
+		if !ok {
+			return nil, fmt.Errorf("jobspec: 'task' should be an object")
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, taskObj); err != nil {
+			return nil, fmt.Errorf("jobspec: %v", err)
+		}
+
+		config, err := parseTaskConfig(m)
+		if err != nil {
+			return nil, err
+		}
+		delete(m, "config")
+
+		var t structs.Task
+		if err := mapstructure.WeakDecode(m, &t); err != nil {
+			return nil, fmt.Errorf("jobspec: %v", err)
+		}
+		if len(item.Keys) > 0 {
+			t.Name = item.Keys[0].Token.Value().(string)
+		}
+		t.Config = config
+
+		if opts.Validators != nil {
+			if err := opts.Validators.Validate(t.Driver, t.Config); err != nil {
+				return nil, fmt.Errorf("task %q validation failed: %v", t.Name, err)
+			}
+		}
+
+		tasks = append(tasks, &t)
+	}
+	return tasks, nil
+}
+
+// parseTaskConfig pulls the nested "config" block out of a decoded task
+// object and flattens it into the map[string]string structs.Task.Config
+// expects. HCL v1 decodes a single nested block as []map[string]interface{}
+// (not a flat map), so this unwraps that shape explicitly rather than
+// relying on mapstructure to guess it.
+func parseTaskConfig(m map[string]interface{}) (map[string]string, error) {
+	raw, ok := m["config"]
+	if !ok {
+		return nil, nil
+	}
+
+	var configMap map[string]interface{}
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		configMap = v
+	case []map[string]interface{}:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		configMap = v[0]
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		inner, ok := v[0].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("jobspec: 'config' should be an object")
+		}
+		configMap = inner
+	default:
+		return nil, fmt.Errorf("jobspec: 'config' should be an object")
+	}
+
+	config := make(map[string]string, len(configMap))
+	for k, v := range configMap {
+		config[k] = fmt.Sprintf("%v", v)
+	}
+	return config, nil
+}
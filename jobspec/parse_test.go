@@ -0,0 +1,72 @@
+package jobspec
+
+import (
+	"strings"
+	"testing"
+)
+
+const rawExecJob = `
+job "example" {
+	task "run" {
+		driver = "raw_exec"
+		config {
+			command = "/bin/sleep"
+			args = "1"
+		}
+	}
+}
+`
+
+func TestParse_TaskConfigBlock(t *testing.T) {
+	job, err := Parse(strings.NewReader(rawExecJob))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(job.TaskGroups) != 1 || len(job.TaskGroups[0].Tasks) != 1 {
+		t.Fatalf("expected a single task, got %#v", job.TaskGroups)
+	}
+
+	task := job.TaskGroups[0].Tasks[0]
+	if task.Config["command"] != "/bin/sleep" {
+		t.Fatalf("expected command to decode from the config block, got %q", task.Config["command"])
+	}
+	if task.Config["args"] != "1" {
+		t.Fatalf("expected args to decode from the config block, got %q", task.Config["args"])
+	}
+}
+
+func TestParse_ValidatesTaskConfig(t *testing.T) {
+	const missingCommand = `
+job "example" {
+	task "run" {
+		driver = "raw_exec"
+		config {
+			args = "1"
+		}
+	}
+}
+`
+	if _, err := Parse(strings.NewReader(missingCommand)); err == nil {
+		t.Fatalf("expected validation error for missing command")
+	}
+}
+
+func TestParseWithOptions_NoValidators(t *testing.T) {
+	const missingCommand = `
+job "example" {
+	task "run" {
+		driver = "raw_exec"
+		config {
+			args = "1"
+		}
+	}
+}
+`
+	job, err := ParseWithOptions(strings.NewReader(missingCommand), ParseOptions{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if job.TaskGroups[0].Tasks[0].Config["args"] != "1" {
+		t.Fatalf("expected config to still be populated without validators")
+	}
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEvalCache_CompileRegexp_Bounded(t *testing.T) {
+	c := &EvalCache{}
+	for i := 0; i < defaultRegexpCacheSize+10; i++ {
+		if _, err := c.CompileRegexp(fmt.Sprintf("^pattern-%d$", i)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if n := c.regexpLRU.Len(); n > defaultRegexpCacheSize {
+		t.Fatalf("expected cache to be bounded at %d, got %d", defaultRegexpCacheSize, n)
+	}
+}
+
+func TestEvalCache_CompileRegexp_Reuses(t *testing.T) {
+	c := &EvalCache{}
+	re1, err := c.CompileRegexp("^linux$")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	re2, err := c.CompileRegexp("^linux$")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if re1 != re2 {
+		t.Fatalf("expected the same compiled regexp to be reused")
+	}
+}
+
+func TestEvalCache_CompileConstraint_Bounded(t *testing.T) {
+	c := &EvalCache{}
+	for i := 0; i < defaultConstraintCacheSize+10; i++ {
+		if _, err := c.CompileConstraint(fmt.Sprintf(">= %d.0.0", i)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if n := c.constraintLRU.Len(); n > defaultConstraintCacheSize {
+		t.Fatalf("expected cache to be bounded at %d, got %d", defaultConstraintCacheSize, n)
+	}
+}
+
+func TestEvalCache_SetCacheSizes(t *testing.T) {
+	c := &EvalCache{}
+	c.SetCacheSizes(4, 0)
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.CompileRegexp(fmt.Sprintf("^pattern-%d$", i)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if n := c.regexpLRU.Len(); n > 4 {
+		t.Fatalf("expected regexp cache bounded at the configured size 4, got %d", n)
+	}
+
+	for i := 0; i < defaultConstraintCacheSize+10; i++ {
+		if _, err := c.CompileConstraint(fmt.Sprintf(">= %d.0.0", i)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if n := c.constraintLRU.Len(); n > defaultConstraintCacheSize {
+		t.Fatalf("expected constraint cache to keep its default size when size 0 is passed, got %d", n)
+	}
+}
+
+func TestEvalCache_SharedCache(t *testing.T) {
+	if err := SetSharedCaches(8, 8); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer SetSharedCaches(0, 0)
+
+	c1 := &EvalCache{}
+	re1, err := c1.CompileRegexp("^shared$")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A second, independent EvalCache should hit the shared tier instead
+	// of recompiling.
+	c2 := &EvalCache{}
+	re2, err := c2.CompileRegexp("^shared$")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if re1 != re2 {
+		t.Fatalf("expected the shared cache to return the same compiled regexp across EvalCaches")
+	}
+}
+
+// BenchmarkCompileRegexp_5kNodeCluster simulates a scheduler evaluation
+// checking a handful of common constraint patterns against a 5k-node
+// cluster, the workload that originally motivated bounding this cache.
+func BenchmarkCompileRegexp_5kNodeCluster(b *testing.B) {
+	patterns := []string{"^linux$", "^amd64$", `^1\.[0-9]+\.[0-9]+$`}
+	c := &EvalCache{}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < 5000; n++ {
+			pattern := patterns[n%len(patterns)]
+			if _, err := c.CompileRegexp(pattern); err != nil {
+				b.Fatalf("err: %v", err)
+			}
+		}
+	}
+}
@@ -3,11 +3,25 @@ package scheduler
 import (
 	"log"
 	"regexp"
+	"sort"
+	"sync/atomic"
 
 	"github.com/hashicorp/go-version"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+const (
+	// defaultRegexpCacheSize is the number of compiled regexps kept per
+	// evaluation by CompileRegexp, used unless SetCacheSizes overrides it.
+	defaultRegexpCacheSize = 1024
+
+	// defaultConstraintCacheSize is the number of parsed version
+	// constraints kept per evaluation by CompileConstraint, used unless
+	// SetCacheSizes overrides it.
+	defaultConstraintCacheSize = 1024
+)
+
 // Context is used to track contextual information used for placement
 type Context interface {
 	// State is used to inspect the current global state
@@ -19,6 +33,11 @@ type Context interface {
 	// Logger provides a way to log
 	Logger() *log.Logger
 
+	// StructLogger returns a structured logger pre-populated with the
+	// eval/job identifiers for this context, so placement decisions can
+	// be logged with key/value fields instead of pre-formatted strings.
+	StructLogger() Logger
+
 	// Metrics returns the current metrics
 	Metrics() *structs.AllocMetric
 
@@ -30,39 +49,202 @@ type Context interface {
 	// adding any planned placements.
 	ProposedAllocs(nodeID string) ([]*structs.Allocation, error)
 
-	// RegexpCache is a cache of regular expressions
+	// ProposedAllocsWithPreemption is like ProposedAllocs, plus a set of
+	// lower-priority allocations that must be evicted to free at least
+	// `need` on the node for a placement at jobPriority (see
+	// selectPreemptionVictims for when that set is minimal).
+	ProposedAllocsWithPreemption(nodeID string, jobPriority int, need *structs.Resources) (proposed []*structs.Allocation, preemptible []*structs.Allocation, err error)
+
+	// MarkPreempted records allocs as chosen preemption victims so later
+	// calls to ProposedAllocsWithPreemption don't offer them up again.
+	MarkPreempted(allocs []*structs.Allocation)
+
+	// RegexpCache returns a bounded snapshot of the compiled regexps seen
+	// via CompileRegexp so far this evaluation.
 	RegexpCache() map[string]*regexp.Regexp
 
-	// ConstraintCache is a cache of version constraints
+	// ConstraintCache returns a bounded snapshot of the parsed version
+	// constraints seen via CompileConstraint so far this evaluation.
 	ConstraintCache() map[string]version.Constraints
+
+	// CompileRegexp returns a compiled regexp for pattern, reusing a
+	// previous compilation from this evaluation's bounded cache (and the
+	// process-wide shared cache, if one is installed) when available.
+	CompileRegexp(pattern string) (*regexp.Regexp, error)
+
+	// CompileConstraint returns parsed version constraints for raw,
+	// reusing a previous parse from this evaluation's bounded cache (and
+	// the process-wide shared cache, if one is installed) when available.
+	CompileConstraint(raw string) (version.Constraints, error)
+}
+
+// sharedRegexpCache and sharedConstraintCache are optional process-wide LRU
+// caches that outlive any single EvalContext, installed via SetSharedCaches.
+// They're held in atomic.Value, not plain *lru.Cache vars, because
+// CompileRegexp/CompileConstraint read them from scheduler worker
+// goroutines concurrently with a possible SetSharedCaches reconfiguration.
+var (
+	sharedRegexpCache     atomic.Value // *lru.Cache
+	sharedConstraintCache atomic.Value // *lru.Cache
+)
+
+// SetSharedCaches installs process-wide LRU caches of the given sizes that
+// EvalContext consults after a miss in its own per-evaluation cache and
+// before compiling. Passing a size of 0 for either cache disables that
+// shared tier.
+func SetSharedCaches(regexpSize, constraintSize int) error {
+	var re, con *lru.Cache
+	var err error
+
+	if regexpSize > 0 {
+		if re, err = lru.New(regexpSize); err != nil {
+			return err
+		}
+	}
+	if constraintSize > 0 {
+		if con, err = lru.New(constraintSize); err != nil {
+			return err
+		}
+	}
+
+	sharedRegexpCache.Store(re)
+	sharedConstraintCache.Store(con)
+	return nil
 }
 
-// EvalCache is used to cache certain things during an evaluation
+func getSharedRegexpCache() *lru.Cache {
+	c, _ := sharedRegexpCache.Load().(*lru.Cache)
+	return c
+}
+
+func getSharedConstraintCache() *lru.Cache {
+	c, _ := sharedConstraintCache.Load().(*lru.Cache)
+	return c
+}
+
+// EvalCache is used to cache certain things during an evaluation. Its
+// storage is a pair of size-bounded LRUs rather than plain maps, so a
+// pathological evaluation (thousands of distinct constraints across
+// thousands of nodes) can't grow it unbounded.
 type EvalCache struct {
-	reCache         map[string]*regexp.Regexp
-	constraintCache map[string]version.Constraints
+	regexpLRU     *lru.Cache
+	constraintLRU *lru.Cache
 }
 
+// RegexpCache returns a snapshot map of the regexp LRU's current contents.
+// The map is a copy: writing to it does not feed back into the cache, so
+// callers wanting a compiled regexp cached should use CompileRegexp.
 func (e *EvalCache) RegexpCache() map[string]*regexp.Regexp {
-	if e.reCache == nil {
-		e.reCache = make(map[string]*regexp.Regexp)
+	if e.regexpLRU == nil {
+		e.regexpLRU, _ = lru.New(defaultRegexpCacheSize)
 	}
-	return e.reCache
+	out := make(map[string]*regexp.Regexp, e.regexpLRU.Len())
+	for _, k := range e.regexpLRU.Keys() {
+		if v, ok := e.regexpLRU.Peek(k); ok {
+			out[k.(string)] = v.(*regexp.Regexp)
+		}
+	}
+	return out
 }
+
+// ConstraintCache returns a snapshot map of the constraint LRU's current
+// contents; see the RegexpCache doc for why writes to it don't persist.
 func (e *EvalCache) ConstraintCache() map[string]version.Constraints {
-	if e.constraintCache == nil {
-		e.constraintCache = make(map[string]version.Constraints)
+	if e.constraintLRU == nil {
+		e.constraintLRU, _ = lru.New(defaultConstraintCacheSize)
+	}
+	out := make(map[string]version.Constraints, e.constraintLRU.Len())
+	for _, k := range e.constraintLRU.Keys() {
+		if v, ok := e.constraintLRU.Peek(k); ok {
+			out[k.(string)] = v.(version.Constraints)
+		}
+	}
+	return out
+}
+
+// SetCacheSizes overrides the size of this cache's per-evaluation regexp
+// and constraint LRUs, read from scheduler config (e.g. a RegexpCacheSize
+// setting) instead of the defaultRegexpCacheSize/defaultConstraintCacheSize
+// package defaults. Call it before the first CompileRegexp/CompileConstraint;
+// a size of 0 leaves that cache at its default rather than disabling it.
+func (e *EvalCache) SetCacheSizes(regexpSize, constraintSize int) {
+	if regexpSize > 0 {
+		e.regexpLRU, _ = lru.New(regexpSize)
+	}
+	if constraintSize > 0 {
+		e.constraintLRU, _ = lru.New(constraintSize)
+	}
+}
+
+// CompileRegexp returns a compiled regexp for pattern, checking this
+// evaluation's bounded cache and the shared process-wide cache (if any)
+// before compiling and populating both.
+func (e *EvalCache) CompileRegexp(pattern string) (*regexp.Regexp, error) {
+	if e.regexpLRU == nil {
+		e.regexpLRU, _ = lru.New(defaultRegexpCacheSize)
+	}
+	if v, ok := e.regexpLRU.Get(pattern); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	if shared := getSharedRegexpCache(); shared != nil {
+		if v, ok := shared.Get(pattern); ok {
+			re := v.(*regexp.Regexp)
+			e.regexpLRU.Add(pattern, re)
+			return re, nil
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	e.regexpLRU.Add(pattern, re)
+	if shared := getSharedRegexpCache(); shared != nil {
+		shared.Add(pattern, re)
+	}
+	return re, nil
+}
+
+// CompileConstraint returns parsed version constraints for raw, checking
+// this evaluation's bounded cache and the shared process-wide cache (if
+// any) before parsing and populating both.
+func (e *EvalCache) CompileConstraint(raw string) (version.Constraints, error) {
+	if e.constraintLRU == nil {
+		e.constraintLRU, _ = lru.New(defaultConstraintCacheSize)
+	}
+	if v, ok := e.constraintLRU.Get(raw); ok {
+		return v.(version.Constraints), nil
+	}
+	if shared := getSharedConstraintCache(); shared != nil {
+		if v, ok := shared.Get(raw); ok {
+			c := v.(version.Constraints)
+			e.constraintLRU.Add(raw, c)
+			return c, nil
+		}
+	}
+
+	constraint, err := version.NewConstraint(raw)
+	if err != nil {
+		return nil, err
 	}
-	return e.constraintCache
+	e.constraintLRU.Add(raw, constraint)
+	if shared := getSharedConstraintCache(); shared != nil {
+		shared.Add(raw, constraint)
+	}
+	return constraint, nil
 }
 
 // EvalContext is a Context used during an Evaluation
 type EvalContext struct {
 	EvalCache
-	state   State
-	plan    *structs.Plan
-	logger  *log.Logger
-	metrics *structs.AllocMetric
+	state        State
+	plan         *structs.Plan
+	logger       *log.Logger
+	structLogger Logger
+	metrics      *structs.AllocMetric
+
+	// preempted tracks allocation IDs already chosen as preemption victims
+	preempted map[string]bool
 }
 
 // NewEvalContext constructs a new EvalContext
@@ -73,6 +255,13 @@ func NewEvalContext(s State, p *structs.Plan, log *log.Logger) *EvalContext {
 		logger:  log,
 		metrics: new(structs.AllocMetric),
 	}
+
+	kv := []interface{}{"eval_id", p.EvalID}
+	if p.Job != nil {
+		kv = append(kv, "job_id", p.Job.ID)
+	}
+	ctx.structLogger = newStdLogger(log, kv...)
+
 	return ctx
 }
 
@@ -88,6 +277,16 @@ func (e *EvalContext) Logger() *log.Logger {
 	return e.logger
 }
 
+// StructLogger returns the structured logger for this context, deriving a
+// shim from the plain *log.Logger if one wasn't set (e.g. an EvalContext
+// built directly rather than via NewEvalContext).
+func (e *EvalContext) StructLogger() Logger {
+	if e.structLogger == nil {
+		e.structLogger = newStdLogger(e.logger)
+	}
+	return e.structLogger
+}
+
 func (e *EvalContext) Metrics() *structs.AllocMetric {
 	return e.metrics
 }
@@ -124,3 +323,162 @@ func (e *EvalContext) ProposedAllocs(nodeID string) ([]*structs.Allocation, erro
 	}
 	return proposed, nil
 }
+
+// ProposedAllocsWithPreemption is like ProposedAllocs, but also returns the
+// set of lower-priority allocations on the node, chosen by
+// selectPreemptionVictims, that would need to be evicted to free at least
+// `need`, in case jobPriority can't otherwise be placed. If need is nil,
+// every eligible lower-priority allocation is returned. System jobs and
+// allocations already claimed via MarkPreempted are never offered up.
+func (e *EvalContext) ProposedAllocsWithPreemption(nodeID string, jobPriority int, need *structs.Resources) ([]*structs.Allocation, []*structs.Allocation, error) {
+	proposed, err := e.ProposedAllocs(nodeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var candidates []*structs.Allocation
+	for _, alloc := range proposed {
+		if alloc.Job == nil || alloc.Job.Priority >= jobPriority {
+			continue
+		}
+		if alloc.Job.Type == structs.JobTypeSystem {
+			continue
+		}
+		if e.preempted[alloc.ID] {
+			continue
+		}
+		candidates = append(candidates, alloc)
+	}
+
+	if len(candidates) == 0 || need == nil {
+		return proposed, candidates, nil
+	}
+
+	preemptible := selectPreemptionVictims(candidates, need)
+	if len(preemptible) > 0 {
+		e.metrics.NodesPreempted++
+	}
+	e.StructLogger().Debug("selected preemption victims", "node_id", nodeID, "count", len(preemptible), "job_priority", jobPriority)
+	return proposed, preemptible, nil
+}
+
+// preemptionExactSearchLimit bounds the candidate count selectPreemptionVictims
+// will exhaustively search for a true minimum-cardinality covering subset.
+// Beyond this, it falls back to a greedy heuristic rather than enumerating
+// an intractable number of combinations.
+const preemptionExactSearchLimit = 20
+
+// selectPreemptionVictims returns the smallest subset of candidates whose
+// combined CPU and memory cover need, sorted by ID so repeated calls with
+// the same inputs always agree. For small candidate counts this is an
+// exact minimum via combinationCovering; beyond preemptionExactSearchLimit
+// it falls back to greedyCoveringVictims, which is not guaranteed minimal.
+func selectPreemptionVictims(candidates []*structs.Allocation, need *structs.Resources) []*structs.Allocation {
+	sorted := make([]*structs.Allocation, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	if len(sorted) <= preemptionExactSearchLimit {
+		for k := 1; k <= len(sorted); k++ {
+			if victims := combinationCovering(sorted, need, k); victims != nil {
+				return victims
+			}
+		}
+		return nil
+	}
+	return greedyCoveringVictims(sorted, need)
+}
+
+// combinationCovering checks every k-sized combination of candidates, in
+// lexicographic index order, and returns the first whose combined CPU and
+// memory cover need, or nil if none does.
+func combinationCovering(candidates []*structs.Allocation, need *structs.Resources, k int) []*structs.Allocation {
+	n := len(candidates)
+	idx := make([]int, k)
+	for i := range idx {
+		idx[i] = i
+	}
+
+	for {
+		var cpu, mem int
+		for _, i := range idx {
+			if r := candidates[i].Resources; r != nil {
+				cpu += r.CPU
+				mem += r.MemoryMB
+			}
+		}
+		if cpu >= need.CPU && mem >= need.MemoryMB {
+			victims := make([]*structs.Allocation, k)
+			for i, x := range idx {
+				victims[i] = candidates[x]
+			}
+			return victims
+		}
+
+		i := k - 1
+		for i >= 0 && idx[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return nil
+		}
+		idx[i]++
+		for j := i + 1; j < k; j++ {
+			idx[j] = idx[j-1] + 1
+		}
+	}
+}
+
+// greedyCoveringVictims repeatedly picks the candidate that frees the most
+// resources, stopping once the combined total covers need, or returns nil
+// if all candidates together don't cover it. It's a best-effort heuristic
+// used only once preemptionExactSearchLimit makes an exhaustive search
+// impractical, and can pick more allocations than a true minimum covering
+// subset would.
+func greedyCoveringVictims(candidates []*structs.Allocation, need *structs.Resources) []*structs.Allocation {
+	sorted := make([]*structs.Allocation, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		si, sj := sorted[i].Resources, sorted[j].Resources
+		scoreI, scoreJ := resourceScore(si), resourceScore(sj)
+		if scoreI != scoreJ {
+			return scoreI > scoreJ
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var victims []*structs.Allocation
+	var freedCPU, freedMem int
+	for _, alloc := range sorted {
+		victims = append(victims, alloc)
+		if alloc.Resources != nil {
+			freedCPU += alloc.Resources.CPU
+			freedMem += alloc.Resources.MemoryMB
+		}
+		if freedCPU >= need.CPU && freedMem >= need.MemoryMB {
+			return victims
+		}
+	}
+	return nil
+}
+
+func resourceScore(r *structs.Resources) int {
+	if r == nil {
+		return 0
+	}
+	return r.CPU + r.MemoryMB
+}
+
+// MarkPreempted records allocs as chosen preemption victims so later calls
+// to ProposedAllocsWithPreemption in this evaluation don't propose them
+// again.
+func (e *EvalContext) MarkPreempted(allocs []*structs.Allocation) {
+	if e.preempted == nil {
+		e.preempted = make(map[string]bool, len(allocs))
+	}
+	for _, alloc := range allocs {
+		e.preempted[alloc.ID] = true
+	}
+}
@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// mockPreemptionState is a minimal State stub that implements only the
+// method ProposedAllocsWithPreemption's tests actually exercise.
+type mockPreemptionState struct {
+	allocs []*structs.Allocation
+}
+
+func (m *mockPreemptionState) AllocsByNode(nodeID string) ([]*structs.Allocation, error) {
+	return m.allocs, nil
+}
+
+func testPreemptionContext(allocs []*structs.Allocation) *EvalContext {
+	s := &mockPreemptionState{allocs: allocs}
+	plan := &structs.Plan{EvalID: "eval1"}
+	logger := log.New(&bytes.Buffer{}, "", 0)
+	return NewEvalContext(s, plan, logger)
+}
+
+func allocWithResources(id string, priority int, jobType string, cpu, memoryMB int) *structs.Allocation {
+	return &structs.Allocation{
+		ID: id,
+		Job: &structs.Job{
+			Priority: priority,
+			Type:     jobType,
+		},
+		Resources: &structs.Resources{
+			CPU:      cpu,
+			MemoryMB: memoryMB,
+		},
+	}
+}
+
+func TestProposedAllocsWithPreemption_ProtectsSystemJobs(t *testing.T) {
+	allocs := []*structs.Allocation{
+		allocWithResources("system", 10, structs.JobTypeSystem, 100, 100),
+		allocWithResources("low", 10, "service", 100, 100),
+	}
+	ctx := testPreemptionContext(allocs)
+	need := &structs.Resources{CPU: 100, MemoryMB: 100}
+
+	_, preemptible, err := ctx.ProposedAllocsWithPreemption("node1", 50, need)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(preemptible) != 1 || preemptible[0].ID != "low" {
+		t.Fatalf("expected only the service alloc to be preemptible, got %#v", preemptible)
+	}
+}
+
+func TestProposedAllocsWithPreemption_Ties(t *testing.T) {
+	allocs := []*structs.Allocation{
+		allocWithResources("a", 10, "service", 50, 50),
+		allocWithResources("b", 10, "service", 50, 50),
+	}
+	ctx := testPreemptionContext(allocs)
+	need := &structs.Resources{CPU: 50, MemoryMB: 50}
+
+	_, first, err := ctx.ProposedAllocsWithPreemption("node1", 50, need)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(first) != 1 || first[0].ID != "a" {
+		t.Fatalf("expected the lower-ID alloc to break the tie, got %#v", first)
+	}
+
+	_, second, err := ctx.ProposedAllocsWithPreemption("node1", 50, need)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if second[0].ID != first[0].ID {
+		t.Fatalf("tie-break was not deterministic across repeated evaluations")
+	}
+}
+
+func TestSelectPreemptionVictims_MinimizesCardinality(t *testing.T) {
+	// need is satisfied by {x,y} (2 allocs); a score-first greedy sort
+	// would instead pick z first (highest combined CPU+MemoryMB) and
+	// still need x and y, preempting 3 allocs where 2 would do.
+	need := &structs.Resources{CPU: 100, MemoryMB: 100}
+	candidates := []*structs.Allocation{
+		allocWithResources("x", 10, "service", 100, 0),
+		allocWithResources("y", 10, "service", 0, 100),
+		allocWithResources("z", 10, "service", 60, 60),
+	}
+
+	victims := selectPreemptionVictims(candidates, need)
+	if len(victims) != 2 {
+		t.Fatalf("expected the minimal 2-alloc covering set, got %#v", victims)
+	}
+	got := map[string]bool{victims[0].ID: true, victims[1].ID: true}
+	if !got["x"] || !got["y"] {
+		t.Fatalf("expected victims {x,y}, got %#v", victims)
+	}
+}
+
+func TestProposedAllocsWithPreemption_RecordsMetric(t *testing.T) {
+	allocs := []*structs.Allocation{
+		allocWithResources("low", 10, "service", 100, 100),
+	}
+	ctx := testPreemptionContext(allocs)
+	need := &structs.Resources{CPU: 100, MemoryMB: 100}
+
+	if _, _, err := ctx.ProposedAllocsWithPreemption("node1", 50, need); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ctx.Metrics().NodesPreempted != 1 {
+		t.Fatalf("expected NodesPreempted to be recorded, got %d", ctx.Metrics().NodesPreempted)
+	}
+}
+
+func TestProposedAllocsWithPreemption_MarkPreemptedExcludesVictim(t *testing.T) {
+	allocs := []*structs.Allocation{
+		allocWithResources("low", 10, "service", 100, 100),
+	}
+	ctx := testPreemptionContext(allocs)
+	need := &structs.Resources{CPU: 100, MemoryMB: 100}
+
+	_, preemptible, err := ctx.ProposedAllocsWithPreemption("node1", 50, need)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(preemptible) != 1 {
+		t.Fatalf("expected one preemptible alloc, got %#v", preemptible)
+	}
+	ctx.MarkPreempted(preemptible)
+
+	_, preemptible, err = ctx.ProposedAllocsWithPreemption("node1", 50, need)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(preemptible) != 0 {
+		t.Fatalf("expected the already-preempted alloc not to be offered again, got %#v", preemptible)
+	}
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// FeasibilityChecker is satisfied by anything that filters nodes down to
+// those feasible for a placement.
+type FeasibilityChecker interface {
+	Feasible(option *structs.Node) bool
+}
+
+// ConstraintChecker filters nodes against a set of constraints, checking
+// each against the node's attributes.
+type ConstraintChecker struct {
+	ctx         Context
+	constraints []*structs.Constraint
+}
+
+// NewConstraintChecker returns a ConstraintChecker that evaluates
+// constraints against nodes using ctx's compiled regexp/constraint caches.
+func NewConstraintChecker(ctx Context, constraints []*structs.Constraint) *ConstraintChecker {
+	return &ConstraintChecker{ctx: ctx, constraints: constraints}
+}
+
+// Feasible returns whether option satisfies every constraint. The first
+// one that doesn't is logged with a structured rejection reason and
+// recorded on the context's AllocMetric.
+func (c *ConstraintChecker) Feasible(option *structs.Node) bool {
+	for _, constraint := range c.constraints {
+		if ok, reason := c.meets(option, constraint); !ok {
+			c.ctx.StructLogger().Debug("node rejected by constraint",
+				"node_id", option.ID,
+				"target", constraint.LTarget,
+				"operand", constraint.Operand,
+				"reason", reason,
+			)
+			c.ctx.Metrics().FilterNode(option, constraint.String())
+			return false
+		}
+	}
+	return true
+}
+
+func (c *ConstraintChecker) meets(option *structs.Node, constraint *structs.Constraint) (bool, string) {
+	lVal, ok, recognized := resolveConstraintTarget(option, constraint.LTarget)
+	if !recognized {
+		return false, "unsupported constraint target " + constraint.LTarget
+	}
+	if !ok {
+		return false, "missing left-hand attribute"
+	}
+
+	switch constraint.Operand {
+	case "=", "==", "is":
+		if lVal != constraint.RTarget {
+			return false, "value mismatch"
+		}
+	case "!=", "not":
+		if lVal == constraint.RTarget {
+			return false, "value mismatch"
+		}
+	case structs.ConstraintRegex:
+		re, err := c.ctx.CompileRegexp(constraint.RTarget)
+		if err != nil {
+			return false, "invalid regexp: " + err.Error()
+		}
+		if !re.MatchString(lVal) {
+			return false, "regexp did not match"
+		}
+	case structs.ConstraintVersion:
+		constraints, err := c.ctx.CompileConstraint(constraint.RTarget)
+		if err != nil {
+			return false, "invalid version constraint: " + err.Error()
+		}
+		v, err := version.NewVersion(lVal)
+		if err != nil {
+			return false, "invalid version: " + err.Error()
+		}
+		if !constraints.Check(v) {
+			return false, "version constraint not satisfied"
+		}
+	default:
+		return false, "unknown constraint operand " + constraint.Operand
+	}
+	return true, ""
+}
+
+// resolveConstraintTarget resolves an LTarget against option, supporting
+// the standard Nomad constraint target forms: "${attr.X}" (node
+// fingerprint attributes), "${meta.X}" (node metadata), and the
+// "${node.*}" built-ins below. recognized is false for any other target
+// form, which callers should treat as a hard constraint failure rather
+// than silently matching or missing.
+func resolveConstraintTarget(option *structs.Node, target string) (value string, ok bool, recognized bool) {
+	switch {
+	case strings.HasPrefix(target, "${attr.") && strings.HasSuffix(target, "}"):
+		key := strings.TrimSuffix(strings.TrimPrefix(target, "${attr."), "}")
+		v, ok := option.Attributes[key]
+		return v, ok, true
+	case strings.HasPrefix(target, "${meta.") && strings.HasSuffix(target, "}"):
+		key := strings.TrimSuffix(strings.TrimPrefix(target, "${meta."), "}")
+		v, ok := option.Meta[key]
+		return v, ok, true
+	case target == "${node.unique.id}":
+		return option.ID, true, true
+	case target == "${node.datacenter}":
+		return option.Datacenter, true, true
+	case target == "${node.class}":
+		return option.NodeClass, true, true
+	case target == "${node.name}":
+		return option.Name, true, true
+	default:
+		return "", false, false
+	}
+}
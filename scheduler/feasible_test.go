@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func testNode(attrs map[string]string) *structs.Node {
+	return &structs.Node{ID: "node1", Attributes: attrs}
+}
+
+func TestConstraintChecker_NodeAndMetaTargets(t *testing.T) {
+	ctx := testPreemptionContext(nil)
+	node := &structs.Node{
+		ID:         "node1",
+		Datacenter: "dc1",
+		NodeClass:  "compute",
+		Meta:       map[string]string{"rack": "r1"},
+	}
+
+	cases := []struct {
+		target string
+		rtgt   string
+		want   bool
+	}{
+		{"${node.unique.id}", "node1", true},
+		{"${node.datacenter}", "dc1", true},
+		{"${node.datacenter}", "dc2", false},
+		{"${node.class}", "compute", true},
+		{"${meta.rack}", "r1", true},
+		{"${meta.rack}", "r2", false},
+	}
+	for _, tc := range cases {
+		c := NewConstraintChecker(ctx, []*structs.Constraint{
+			{LTarget: tc.target, RTarget: tc.rtgt, Operand: "="},
+		})
+		if got := c.Feasible(node); got != tc.want {
+			t.Fatalf("target %q = %q: expected feasible=%v, got %v", tc.target, tc.rtgt, tc.want, got)
+		}
+	}
+}
+
+func TestConstraintChecker_UnsupportedTarget(t *testing.T) {
+	ctx := testPreemptionContext(nil)
+	c := NewConstraintChecker(ctx, []*structs.Constraint{
+		{LTarget: "${unsupported.thing}", RTarget: "x", Operand: "="},
+	})
+	if c.Feasible(testNode(nil)) {
+		t.Fatalf("expected an unsupported target form to fail the constraint rather than silently match")
+	}
+}
+
+func TestConstraintChecker_Equality(t *testing.T) {
+	ctx := testPreemptionContext(nil)
+	c := NewConstraintChecker(ctx, []*structs.Constraint{
+		{LTarget: "${attr.kernel.name}", RTarget: "linux", Operand: "="},
+	})
+
+	if !c.Feasible(testNode(map[string]string{"kernel.name": "linux"})) {
+		t.Fatalf("expected node to be feasible")
+	}
+	if c.Feasible(testNode(map[string]string{"kernel.name": "windows"})) {
+		t.Fatalf("expected node to be infeasible")
+	}
+}
+
+func TestConstraintChecker_Regexp(t *testing.T) {
+	ctx := testPreemptionContext(nil)
+	c := NewConstraintChecker(ctx, []*structs.Constraint{
+		{LTarget: "${attr.kernel.version}", RTarget: `^4\.`, Operand: structs.ConstraintRegex},
+	})
+
+	if !c.Feasible(testNode(map[string]string{"kernel.version": "4.9.0"})) {
+		t.Fatalf("expected node to be feasible")
+	}
+	if c.Feasible(testNode(map[string]string{"kernel.version": "3.10.0"})) {
+		t.Fatalf("expected node to be infeasible")
+	}
+}
+
+func TestConstraintChecker_Version(t *testing.T) {
+	ctx := testPreemptionContext(nil)
+	c := NewConstraintChecker(ctx, []*structs.Constraint{
+		{LTarget: "${attr.driver.docker.version}", RTarget: ">= 1.6.0", Operand: structs.ConstraintVersion},
+	})
+
+	if !c.Feasible(testNode(map[string]string{"driver.docker.version": "1.8.0"})) {
+		t.Fatalf("expected node to be feasible")
+	}
+	if c.Feasible(testNode(map[string]string{"driver.docker.version": "1.2.0"})) {
+		t.Fatalf("expected node to be infeasible")
+	}
+}
+
+func TestConstraintChecker_MissingAttribute(t *testing.T) {
+	ctx := testPreemptionContext(nil)
+	c := NewConstraintChecker(ctx, []*structs.Constraint{
+		{LTarget: "${attr.kernel.name}", RTarget: "linux", Operand: "="},
+	})
+
+	if c.Feasible(testNode(map[string]string{})) {
+		t.Fatalf("expected node missing the attribute to be infeasible")
+	}
+}
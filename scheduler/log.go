@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger is a minimal structured logging interface for the scheduler.
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+
+	// With returns a Logger that includes kv on every call in addition to
+	// whatever this Logger already carries.
+	With(kv ...interface{}) Logger
+}
+
+// stdLogger implements Logger on top of the standard library's *log.Logger
+// used elsewhere in the scheduler, so structured fields show up in the
+// same log stream as everything else.
+type stdLogger struct {
+	l  *log.Logger
+	kv []interface{}
+}
+
+// newStdLogger returns a Logger backed by l, pre-populated with kv.
+func newStdLogger(l *log.Logger, kv ...interface{}) Logger {
+	return &stdLogger{l: l, kv: kv}
+}
+
+func (s *stdLogger) Debug(msg string, kv ...interface{}) { s.log("DEBUG", msg, kv) }
+func (s *stdLogger) Info(msg string, kv ...interface{})  { s.log("INFO", msg, kv) }
+func (s *stdLogger) Warn(msg string, kv ...interface{})  { s.log("WARN", msg, kv) }
+func (s *stdLogger) Error(msg string, kv ...interface{}) { s.log("ERR", msg, kv) }
+
+func (s *stdLogger) With(kv ...interface{}) Logger {
+	merged := make([]interface{}, 0, len(s.kv)+len(kv))
+	merged = append(merged, s.kv...)
+	merged = append(merged, kv...)
+	return &stdLogger{l: s.l, kv: merged}
+}
+
+func (s *stdLogger) log(level, msg string, kv []interface{}) {
+	all := make([]interface{}, 0, len(s.kv)+len(kv))
+	all = append(all, s.kv...)
+	all = append(all, kv...)
+
+	s.l.Printf("[%s] sched: %s%s", level, msg, formatKV(all))
+}
+
+// formatKV renders an even-length key/value slice as " key=value key=value
+// ...". An odd trailing element is rendered as "key=<missing>" rather than
+// panicking, since these pairs usually come from call sites, not user
+// input.
+func formatKV(kv []interface{}) string {
+	if len(kv) == 0 {
+		return ""
+	}
+
+	out := ""
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprintf("%v", kv[i])
+		if i+1 < len(kv) {
+			out += fmt.Sprintf(" %s=%s", key, formatValue(kv[i+1]))
+		} else {
+			out += fmt.Sprintf(" %s=<missing>", key)
+		}
+	}
+	return out
+}
+
+// formatValue renders v as a log-line-safe token, quoting it if its
+// default formatting contains whitespace that would otherwise make the
+// "key=value" pairs ambiguous to split on.
+func formatValue(v interface{}) string {
+	s := fmt.Sprintf("%v", v)
+	if strings.ContainsAny(s, " \t") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
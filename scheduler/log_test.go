@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestStdLogger_With(t *testing.T) {
+	var buf bytes.Buffer
+	base := newStdLogger(log.New(&buf, "", 0), "eval_id", "eval1")
+	withNode := base.With("node_id", "node1")
+
+	withNode.Debug("rejected", "reason", "constraint mismatch")
+	out := buf.String()
+
+	for _, want := range []string{"eval_id=eval1", "node_id=node1", "reason=\"constraint mismatch\"", "rejected"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected output to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestFormatKV_OddLength(t *testing.T) {
+	out := formatKV([]interface{}{"key1", "val1", "dangling"})
+	if !strings.Contains(out, "key1=val1") {
+		t.Fatalf("expected paired kv to be formatted, got %q", out)
+	}
+	if !strings.Contains(out, "dangling=<missing>") {
+		t.Fatalf("expected trailing odd key to render as <missing>, got %q", out)
+	}
+}
+
+func TestFormatKV_Empty(t *testing.T) {
+	if out := formatKV(nil); out != "" {
+		t.Fatalf("expected empty kv to format as empty string, got %q", out)
+	}
+}